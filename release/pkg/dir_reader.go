@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"os"
 	gopath "path"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -17,7 +18,8 @@ type DirReaderImpl struct {
 	srcDirPath   string
 	blobsDirPath string
 
-	fs boshsys.FileSystem
+	fs       boshsys.FileSystem
+	sourceFS SourceFS
 }
 
 var (
@@ -35,11 +37,31 @@ func NewDirReaderImpl(
 		srcDirPath:     srcDirPath,
 		blobsDirPath:   blobsDirPath,
 		fs:             fs,
+		sourceFS:       NewSourceFS(fs),
 	}
 }
 
+// NewDirReaderImplWithOverlay behaves like NewDirReaderImpl, except that
+// paths listed in the overlay's Replace map are transparently redirected to
+// files elsewhere on disk, without touching the release tree itself. This
+// allows iterative local patching (e.g. hotfix workflows) that would
+// otherwise require dirtying `git status` in the release repo.
+// The redirection covers the reader's own file system calls (reading the
+// spec, expanding globs, Lstat/Readlink of matches). The archive built by
+// archiveFactory reads file contents through the file system it was created
+// with, so the archive and its fingerprint do not see the overlay.
+func NewDirReaderImplWithOverlay(
+	archiveFactory ArchiveFunc,
+	srcDirPath string,
+	blobsDirPath string,
+	fs boshsys.FileSystem,
+	overlay Overlay,
+) DirReaderImpl {
+	return NewDirReaderImpl(archiveFactory, srcDirPath, blobsDirPath, newOverlayFS(fs, overlay))
+}
+
 func (r DirReaderImpl) Read(path string) (*Package, error) {
-	manifest, files, prepFiles, err := r.collectFiles(path)
+	manifest, files, prepFiles, hasPreInstallScript, hasPostInstallScript, err := r.collectFiles(path)
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Collecting package files")
 	}
@@ -55,56 +77,92 @@ func (r DirReaderImpl) Read(path string) (*Package, error) {
 
 	resource := NewResource(manifest.Name, fp, archive)
 
-	return NewPackage(resource, manifest.Dependencies), nil
+	return NewPackageWithHooks(
+		resource, manifest.Dependencies, manifest.PreInstall, manifest.PostInstall,
+		hasPreInstallScript, hasPostInstallScript,
+	), nil
 }
 
-func (r DirReaderImpl) collectFiles(path string) (Manifest, []File, []File, error) {
+func (r DirReaderImpl) collectFiles(path string) (Manifest, []File, []File, bool, bool, error) {
 	var files, prepFiles []File
 
 	specPath := gopath.Join(path, "spec")
 
 	manifest, err := NewManifestFromPath(specPath, r.fs)
 	if err != nil {
-		return Manifest{}, nil, nil, err
+		return Manifest{}, nil, nil, false, false, err
 	}
 
 	packagingPath := gopath.Join(path, "packaging")
 	files, err = r.checkAndFilterDir(packagingPath, path)
 	if err != nil {
 		if err == fileNotFoundError {
-			return manifest, nil, nil, bosherr.Errorf(
+			return manifest, nil, nil, false, false, bosherr.Errorf(
 				"Expected to find '%s' for package '%s'", packagingPath, manifest.Name)
 		}
 
-		return manifest, nil, nil, bosherr.Errorf("Unexpected error occurred: %s", err)
+		return manifest, nil, nil, false, false, bosherr.Errorf("Unexpected error occurred: %s", err)
 	}
 
 	prePackagingPath := gopath.Join(path, "pre_packaging")
 	prepFiles, err = r.checkAndFilterDir(prePackagingPath, path) //can proceed if there is no pre_packaging
 	if err != nil && err != fileNotFoundError {
-		return manifest, nil, nil, bosherr.Errorf("Unexpected error occurred: %s", err)
+		return manifest, nil, nil, false, false, bosherr.Errorf("Unexpected error occurred: %s", err)
 	}
 
 	files = append(files, prepFiles...)
 
+	var hasPreInstallScript, hasPostInstallScript bool
+
+	for _, hookName := range []string{"pre_install", "post_install"} {
+		hookPath := gopath.Join(path, hookName)
+		hookFiles, err := r.checkAndFilterDir(hookPath, path) //can proceed if there is no hook script
+		if err != nil && err != fileNotFoundError {
+			return manifest, nil, nil, false, false, bosherr.Errorf("Unexpected error occurred: %s", err)
+		}
+
+		if err == nil {
+			switch hookName {
+			case "pre_install":
+				hasPreInstallScript = true
+			case "post_install":
+				hasPostInstallScript = true
+			}
+		}
+
+		files = append(files, hookFiles...)
+	}
+
 	filesByRelPath, err := r.applyFilesPattern(manifest)
 	if err != nil {
-		return manifest, nil, nil, err
+		return manifest, nil, nil, false, false, err
 	}
 
 	excludedFiles, err := r.applyExcludedFilesPattern(manifest)
 	if err != nil {
-		return manifest, nil, nil, err
+		return manifest, nil, nil, false, false, err
 	}
 
 	for _, excludedFile := range excludedFiles {
 		delete(filesByRelPath, excludedFile.RelativePath)
 	}
 
-	for _, specialFileName := range []string{"packaging", "pre_packaging"} {
+	for _, symlink := range manifest.Symlinks {
+		name, err := normalizeGlob(symlink.Name)
+		if err != nil {
+			return manifest, nil, nil, false, false, err
+		}
+
+		filesByRelPath[name] = NewSymlinkFile(gopath.Join(path, name), path, symlink.Target)
+	}
+
+	// Checked after symlinks are merged in, since a symlink is just as
+	// capable of colliding with one of these reserved names (and producing
+	// a duplicate tar entry in the archive) as a 'files' glob match is.
+	for _, specialFileName := range []string{"packaging", "pre_packaging", "pre_install", "post_install"} {
 		if _, ok := filesByRelPath[specialFileName]; ok {
-			errMsg := "Expected special '%s' file to not be included via 'files' key for package '%s'"
-			return manifest, nil, nil, bosherr.Errorf(errMsg, specialFileName, manifest.Name)
+			errMsg := "Expected special '%s' file to not be included via 'files' or 'symlinks' key for package '%s'"
+			return manifest, nil, nil, false, false, bosherr.Errorf(errMsg, specialFileName, manifest.Name)
 		}
 	}
 
@@ -112,42 +170,45 @@ func (r DirReaderImpl) collectFiles(path string) (Manifest, []File, []File, erro
 		files = append(files, file)
 	}
 
-	return manifest, files, prepFiles, nil
+	return manifest, files, prepFiles, hasPreInstallScript, hasPostInstallScript, nil
 }
 
 func (r DirReaderImpl) applyFilesPattern(manifest Manifest) (map[string]File, error) {
 	filesByRelPath := map[string]File{}
-	for _, glob := range manifest.Files {
-		srcDirMatches, err := r.fs.RecursiveGlob(gopath.Join(r.srcDirPath, glob))
+	for _, rawGlob := range manifest.Files {
+		glob, err := normalizeGlob(rawGlob)
+		if err != nil {
+			return map[string]File{}, err
+		}
+
+		srcDirMatches, err := r.sourceFS.Glob(gopath.Join(r.srcDirPath, glob))
 		if err != nil {
 			return map[string]File{}, bosherr.WrapErrorf(err, "Listing package files in src")
 		}
 
 		for _, path := range srcDirMatches {
-			isDir, err := r.isDir(path)
+			file, skip, err := r.newFileFromMatch(path, r.srcDirPath)
 			if err != nil {
 				return map[string]File{}, bosherr.WrapErrorf(err, "Unknown error occurred")
 			}
-			if !isDir {
-				file := NewFile(path, r.srcDirPath)
+			if !skip {
 				if _, found := filesByRelPath[file.RelativePath]; !found {
 					filesByRelPath[file.RelativePath] = file
 				}
 			}
 		}
 
-		blobsDirMatches, err := r.fs.RecursiveGlob(gopath.Join(r.blobsDirPath, glob))
+		blobsDirMatches, err := r.sourceFS.Glob(gopath.Join(r.blobsDirPath, glob))
 		if err != nil {
 			return map[string]File{}, bosherr.WrapErrorf(err, "Listing package files in blobs")
 		}
 
 		for _, path := range blobsDirMatches {
-			isDir, err := r.isDir(path)
+			file, skip, err := r.newFileFromMatch(path, r.blobsDirPath)
 			if err != nil {
 				return map[string]File{}, bosherr.WrapErrorf(err, "Unknown error occurred")
 			}
-			if !isDir {
-				file := NewFile(path, r.blobsDirPath)
+			if !skip {
 				if _, found := filesByRelPath[file.RelativePath]; !found {
 					filesByRelPath[file.RelativePath] = file
 				}
@@ -158,10 +219,41 @@ func (r DirReaderImpl) applyFilesPattern(manifest Manifest) (map[string]File, er
 	return filesByRelPath, nil
 }
 
+// newFileFromMatch builds a File for a path returned by a glob expansion. It
+// reports skip=true for directories, which the caller should ignore.
+// Symlinks are preserved as links (via Lstat/Readlink) rather than silently
+// dereferenced and copied as their resolved contents.
+func (r DirReaderImpl) newFileFromMatch(path, dirPath string) (File, bool, error) {
+	info, err := r.sourceFS.Lstat(path)
+	if err != nil {
+		return File{}, false, err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := r.sourceFS.Readlink(path)
+		if err != nil {
+			return File{}, false, err
+		}
+
+		return NewSymlinkFile(path, dirPath, target), false, nil
+	}
+
+	if info.IsDir() {
+		return File{}, true, nil
+	}
+
+	return NewFile(path, dirPath), false, nil
+}
+
 func (r DirReaderImpl) applyExcludedFilesPattern(manifest Manifest) ([]File, error) {
 	var excludedFiles []File
-	for _, glob := range manifest.ExcludedFiles {
-		srcDirMatches, err := r.fs.RecursiveGlob(gopath.Join(r.srcDirPath, glob))
+	for _, rawGlob := range manifest.ExcludedFiles {
+		glob, err := normalizeGlob(rawGlob)
+		if err != nil {
+			return []File{}, err
+		}
+
+		srcDirMatches, err := r.sourceFS.Glob(gopath.Join(r.srcDirPath, glob))
 		if err != nil {
 			return []File{}, bosherr.WrapErrorf(err, "Listing package excluded files in src")
 		}
@@ -171,7 +263,7 @@ func (r DirReaderImpl) applyExcludedFilesPattern(manifest Manifest) ([]File, err
 			excludedFiles = append(excludedFiles, file)
 		}
 
-		blobsDirMatches, err := r.fs.RecursiveGlob(gopath.Join(r.blobsDirPath, glob))
+		blobsDirMatches, err := r.sourceFS.Glob(gopath.Join(r.blobsDirPath, glob))
 		if err != nil {
 			return []File{}, bosherr.WrapErrorf(err, "Listing package excluded files in blobs")
 		}
@@ -188,7 +280,7 @@ func (r DirReaderImpl) applyExcludedFilesPattern(manifest Manifest) ([]File, err
 func (r DirReaderImpl) checkAndFilterDir(packagePath, path string) ([]File, error) {
 	var files []File
 	if r.fs.FileExists(packagePath) {
-		isDir, err := r.isDir(packagePath)
+		isDir, err := r.sourceFS.IsDir(packagePath)
 		if err != nil {
 			return nil, err
 		}
@@ -203,11 +295,3 @@ func (r DirReaderImpl) checkAndFilterDir(packagePath, path string) ([]File, erro
 
 	return []File{}, fileNotFoundError
 }
-
-func (r DirReaderImpl) isDir(path string) (bool, error) {
-	info, err := r.fs.Stat(path)
-	if err != nil {
-		return false, err
-	}
-	return info.IsDir(), nil
-}
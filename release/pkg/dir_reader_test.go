@@ -0,0 +1,100 @@
+package pkg_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/cloudfoundry/bosh-init/release/pkg"
+	. "github.com/cloudfoundry/bosh-init/release/resource"
+)
+
+var _ = Describe("DirReaderImpl", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		reader DirReaderImpl
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		fs.WriteFileString("/release/packages/pkg1/packaging", "do-the-packaging")
+
+		reader = NewDirReaderImpl(NewArchiveFunc(fs), "/release/src", "/release/blobs", fs)
+	})
+
+	Describe("Read", func() {
+		Context("pre_install/post_install hooks", func() {
+			It("reports script hooks that are present", func() {
+				fs.WriteFileString("/release/packages/pkg1/spec", "---\nname: pkg1\n")
+				fs.WriteFileString("/release/packages/pkg1/pre_install", "echo pre")
+				fs.WriteFileString("/release/packages/pkg1/post_install", "echo post")
+
+				pkg, err := reader.Read("/release/packages/pkg1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pkg.HasPreInstallScript()).To(BeTrue())
+				Expect(pkg.HasPostInstallScript()).To(BeTrue())
+			})
+
+			It("reports no script hooks when neither file is present", func() {
+				fs.WriteFileString("/release/packages/pkg1/spec", "---\nname: pkg1\n")
+
+				pkg, err := reader.Read("/release/packages/pkg1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pkg.HasPreInstallScript()).To(BeFalse())
+				Expect(pkg.HasPostInstallScript()).To(BeFalse())
+			})
+
+			It("exposes inline pre_install/post_install command lists independently of script files", func() {
+				fs.WriteFileString(
+					"/release/packages/pkg1/spec",
+					"---\nname: pkg1\npre_install: [\"echo inline-pre\"]\npost_install: [\"echo inline-post\"]\n")
+
+				pkg, err := reader.Read("/release/packages/pkg1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pkg.PreInstall()).To(Equal([]string{"echo inline-pre"}))
+				Expect(pkg.PostInstall()).To(Equal([]string{"echo inline-post"}))
+				Expect(pkg.HasPreInstallScript()).To(BeFalse())
+				Expect(pkg.HasPostInstallScript()).To(BeFalse())
+			})
+		})
+
+		Context("symlinks", func() {
+			It("includes a manifest-declared symlink's target in the package fingerprint", func() {
+				fs.WriteFileString(
+					"/release/packages/pkg1/spec",
+					"---\nname: pkg1\nsymlinks:\n- name: link.so\n  target: /var/vcap/data/foo\n")
+				pkgWithFoo, err := reader.Read("/release/packages/pkg1")
+				Expect(err).ToNot(HaveOccurred())
+
+				fs.WriteFileString(
+					"/release/packages/pkg1/spec",
+					"---\nname: pkg1\nsymlinks:\n- name: link.so\n  target: /var/vcap/data/bar\n")
+				pkgWithBar, err := reader.Read("/release/packages/pkg1")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(pkgWithFoo.Fingerprint()).ToNot(Equal(pkgWithBar.Fingerprint()))
+			})
+
+			It("rejects a symlink name that escapes the package directory", func() {
+				fs.WriteFileString(
+					"/release/packages/pkg1/spec",
+					"---\nname: pkg1\nsymlinks:\n- name: ../../etc/cron.d/evil\n  target: /bin/sh\n")
+
+				_, err := reader.Read("/release/packages/pkg1")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("must not escape the package directory"))
+			})
+
+			It("rejects a symlink whose name collides with a reserved special file", func() {
+				fs.WriteFileString(
+					"/release/packages/pkg1/spec",
+					"---\nname: pkg1\nsymlinks:\n- name: packaging\n  target: /bin/sh\n")
+
+				_, err := reader.Read("/release/packages/pkg1")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected special 'packaging' file"))
+			})
+		})
+	})
+})
@@ -0,0 +1,278 @@
+package pkg
+
+import (
+	"os"
+	gopath "path"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// globLister is the minimal capability the glob engine needs from a
+// SourceFS implementation: listing a directory's immediate entries, plus
+// enough to resolve a symlinked directory's identity for cycle detection.
+// It's kept separate from SourceFS because it's an implementation detail,
+// not part of the public interface.
+type globLister interface {
+	listDir(dir string) ([]string, error)
+	IsDir(path string) (bool, error)
+	Lstat(path string) (os.FileInfo, error)
+	Readlink(path string) (string, error)
+}
+
+// globPattern expands pattern against fs, supporting `*`/`?`/`[...]` within
+// a path segment, `**` matching zero or more segments (any depth), `{a,b}`
+// alternation, and a trailing slash to restrict matches to directories.
+func globPattern(fs globLister, pattern string) ([]string, error) {
+	onlyDirs := strings.HasSuffix(pattern, "/") && pattern != "/"
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	alternatives, err := expandAlternation(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	root := ""
+	matchesSet := map[string]struct{}{}
+
+	for _, alt := range alternatives {
+		altRoot := root
+		altPattern := alt
+		if strings.HasPrefix(altPattern, "/") {
+			altRoot = "/"
+			altPattern = strings.TrimPrefix(altPattern, "/")
+		}
+
+		var segments []string
+		if altPattern != "" {
+			segments = strings.Split(altPattern, "/")
+		}
+
+		matches, err := matchSegments(fs, altRoot, segments, map[string]struct{}{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			matchesSet[match] = struct{}{}
+		}
+	}
+
+	var result []string
+	for match := range matchesSet {
+		if onlyDirs {
+			isDir, err := fs.IsDir(match)
+			if err != nil || !isDir {
+				continue
+			}
+		}
+
+		result = append(result, match)
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// matchSegments walks pattern segments under prefix. visitedDirs tracks the
+// resolved identity (see resolveDirIdentity) of every directory a `**`
+// segment has already expanded, so that a directory symlink pointing back
+// at one of its own ancestors (e.g. `foo/self -> foo`) can't make `**`
+// recurse into the same directory tree forever - unlike a true symlink
+// loop, the OS itself sees each hop as a perfectly valid directory, so
+// nothing short of our own visited-set catches it.
+func matchSegments(fs globLister, prefix string, segments []string, visitedDirs map[string]struct{}) ([]string, error) {
+	if len(segments) == 0 {
+		if prefix == "" {
+			return nil, nil
+		}
+
+		return []string{prefix}, nil
+	}
+
+	segment := segments[0]
+	rest := segments[1:]
+
+	if segment == "**" {
+		var matches []string
+
+		// `**` may match zero segments...
+		zeroMatches, err := matchSegments(fs, prefix, rest, visitedDirs)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, zeroMatches...)
+
+		if prefix != "" {
+			identity, err := resolveDirIdentity(fs, prefix)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Resolving '%s'", prefix)
+			}
+			if _, seen := visitedDirs[identity]; seen {
+				return matches, nil
+			}
+			visitedDirs[identity] = struct{}{}
+		}
+
+		// ...or descend into every subdirectory and keep matching `**`.
+		children, err := fs.listDir(prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, child := range children {
+			if isHidden(child) {
+				continue
+			}
+
+			childPath := joinGlobPath(prefix, child)
+
+			isDir, err := fs.IsDir(childPath)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Checking '%s'", childPath)
+			}
+			if !isDir {
+				continue
+			}
+
+			sub, err := matchSegments(fs, childPath, segments, visitedDirs)
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, sub...)
+		}
+
+		return matches, nil
+	}
+
+	children, err := fs.listDir(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, child := range children {
+		if isHidden(child) && !isHidden(segment) {
+			continue
+		}
+
+		ok, err := gopath.Match(segment, child)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Matching glob segment '%s'", segment)
+		}
+		if !ok {
+			continue
+		}
+
+		childPath := joinGlobPath(prefix, child)
+
+		if len(rest) == 0 {
+			matches = append(matches, childPath)
+			continue
+		}
+
+		isDir, err := fs.IsDir(childPath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Checking '%s'", childPath)
+		}
+		if !isDir {
+			continue
+		}
+
+		sub, err := matchSegments(fs, childPath, rest, visitedDirs)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, sub...)
+	}
+
+	return matches, nil
+}
+
+// resolveDirIdentity follows a chain of symlinks starting at path and
+// returns the first non-symlink path it reaches, so that two different
+// literal paths reached via different symlinks (e.g. `foo` and
+// `foo/self`, where `self -> foo`) resolve to the same identity for cycle
+// detection. It guards against a literal symlink loop (a path that
+// eventually points back at itself) with its own visited set, distinct
+// from the caller's directory-level one.
+func resolveDirIdentity(fs globLister, path string) (string, error) {
+	seen := map[string]struct{}{}
+
+	for {
+		if _, ok := seen[path]; ok {
+			return "", bosherr.Errorf("Symlink loop at '%s'", path)
+		}
+		seen[path] = struct{}{}
+
+		info, err := fs.Lstat(path)
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+
+		target, err := fs.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+
+		// A symlink's target is commonly relative to its own directory
+		// (e.g. `ln -s ../foo self`), not to the glob root.
+		if !gopath.IsAbs(target) {
+			target = gopath.Join(gopath.Dir(path), target)
+		}
+
+		path = target
+	}
+}
+
+func joinGlobPath(prefix, name string) string {
+	switch prefix {
+	case "":
+		return name
+	case "/":
+		return "/" + name
+	default:
+		return prefix + "/" + name
+	}
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// expandAlternation expands `{a,b,c}` groups, including several in a row,
+// into the set of literal patterns they stand for. Groups are not nested:
+// the first `}` after a `{` closes that group.
+func expandAlternation(pattern string) ([]string, error) {
+	start := strings.Index(pattern, "{")
+	if start == -1 {
+		return []string{pattern}, nil
+	}
+
+	end := strings.Index(pattern[start:], "}")
+	if end == -1 {
+		return nil, bosherr.Errorf("Unbalanced '{' in glob pattern '%s'", pattern)
+	}
+	end += start
+
+	prefix := pattern[:start]
+	suffix := pattern[end+1:]
+	alternatives := strings.Split(pattern[start+1:end], ",")
+
+	var results []string
+	for _, alt := range alternatives {
+		expanded, err := expandAlternation(prefix + alt + suffix)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, expanded...)
+	}
+
+	return results, nil
+}
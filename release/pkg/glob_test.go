@@ -0,0 +1,116 @@
+package pkg_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-init/release/pkg"
+)
+
+var _ = Describe("MemSourceFS glob support", func() {
+	var fs *MemSourceFS
+
+	BeforeEach(func() {
+		fs = NewMemSourceFS()
+		fs.WriteFile("/src/foo/bar.rb", []byte("bar"))
+		fs.WriteFile("/src/foo/baz.rb", []byte("baz"))
+		fs.WriteFile("/src/foo/sub/qux.rb", []byte("qux"))
+		fs.WriteFile("/src/foo/.hidden.rb", []byte("hidden"))
+		fs.WriteFile("/src/other/thing.txt", []byte("thing"))
+		fs.WriteSymlink("/src/foo/link.rb", "/src/foo/bar.rb")
+		fs.WriteSymlink("/src/foo/cycle.rb", "/src/foo/cycle.rb")
+	})
+
+	Describe("Glob", func() {
+		It("matches a plain wildcard within a single segment", func() {
+			matches, err := fs.Glob("/src/foo/*.rb")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf(
+				"/src/foo/bar.rb", "/src/foo/baz.rb", "/src/foo/link.rb", "/src/foo/cycle.rb"))
+		})
+
+		It("matches ** across any depth", func() {
+			matches, err := fs.Glob("/src/**/*.rb")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf(
+				"/src/foo/bar.rb", "/src/foo/baz.rb", "/src/foo/sub/qux.rb",
+				"/src/foo/link.rb", "/src/foo/cycle.rb"))
+		})
+
+		It("expands {a,b} alternation", func() {
+			matches, err := fs.Glob("/src/{foo,other}/*.{rb,txt}")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf(
+				"/src/foo/bar.rb", "/src/foo/baz.rb", "/src/foo/link.rb", "/src/foo/cycle.rb",
+				"/src/other/thing.txt"))
+		})
+
+		It("is case sensitive", func() {
+			matches, err := fs.Glob("/src/foo/BAR.rb")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(BeEmpty())
+		})
+
+		It("does not match hidden files unless the pattern itself is dotted", func() {
+			matches, err := fs.Glob("/src/foo/*.rb")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).ToNot(ContainElement("/src/foo/.hidden.rb"))
+
+			matches, err = fs.Glob("/src/foo/.*.rb")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf("/src/foo/.hidden.rb"))
+		})
+
+		It("restricts matches to directories with a trailing slash", func() {
+			matches, err := fs.Glob("/src/*/")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf("/src/foo", "/src/other"))
+		})
+
+		It("returns symlinked matches without dereferencing them", func() {
+			matches, err := fs.Glob("/src/foo/link.rb")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(Equal([]string{"/src/foo/link.rb"}))
+		})
+
+		It("does not loop forever on a symlink cycle", func() {
+			matches, err := fs.Glob("/src/foo/cycle.rb")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(Equal([]string{"/src/foo/cycle.rb"}))
+		})
+
+		It("does not recurse forever through ** on a directory symlink cycle", func() {
+			cyclic := NewMemSourceFS()
+			cyclic.WriteFile("/src/foo/bar.rb", []byte("bar"))
+			cyclic.WriteSymlink("/src/foo/self", "/src/foo")
+
+			matches, err := cyclic.Glob("/src/**/*.rb")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf("/src/foo/bar.rb"))
+		})
+
+		It("resolves a directory symlink with a relative target instead of failing to Lstat it", func() {
+			relative := NewMemSourceFS()
+			relative.WriteFile("/src/foo/bar.rb", []byte("bar"))
+			relative.WriteFile("/src/sibling/baz.rb", []byte("baz"))
+			relative.WriteSymlink("/src/foo/link", "../sibling")
+
+			// Resolving "../sibling" against "/src/foo" (its own directory),
+			// not against the glob root, is what lets ** keep walking
+			// instead of erroring out trying to Lstat a bogus joined path.
+			matches, err := relative.Glob("/src/**/*.rb")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf("/src/foo/bar.rb", "/src/sibling/baz.rb"))
+		})
+
+		It("does not recurse forever through ** on a directory symlink cycle with a relative target", func() {
+			relativeCyclic := NewMemSourceFS()
+			relativeCyclic.WriteFile("/src/foo/bar.rb", []byte("bar"))
+			relativeCyclic.WriteSymlink("/src/foo/self", ".")
+
+			matches, err := relativeCyclic.Glob("/src/**/*.rb")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf("/src/foo/bar.rb"))
+		})
+	})
+})
@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// Lock is the `packages.lock` contents: a recorded fingerprint per package,
+// plus the fingerprints its dependencies had when it was recorded. Diffing a
+// freshly read package against its Lock entry turns today's opaque
+// fingerprint change into an auditable, CI-enforceable signal.
+type Lock struct {
+	Packages []PackageLock `json:"packages"`
+}
+
+type PackageLock struct {
+	Name                   string   `json:"name"`
+	Fingerprint            string   `json:"fingerprint"`
+	DependencyFingerprints []string `json:"dependency_fingerprints"`
+}
+
+func NewLockFromPath(path string, fs boshsys.FileSystem) (Lock, error) {
+	var lock Lock
+
+	bytes, err := fs.ReadFile(path)
+	if err != nil {
+		return lock, bosherr.WrapErrorf(err, "Reading lock '%s'", path)
+	}
+
+	err = json.Unmarshal(bytes, &lock)
+	if err != nil {
+		return lock, bosherr.WrapErrorf(err, "Parsing lock '%s'", path)
+	}
+
+	return lock, nil
+}
+
+func WriteLock(path string, lock Lock, fs boshsys.FileSystem) error {
+	sort.Slice(lock.Packages, func(i, j int) bool { return lock.Packages[i].Name < lock.Packages[j].Name })
+
+	bytes, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshaling lock")
+	}
+
+	err = fs.WriteFile(path, bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing lock '%s'", path)
+	}
+
+	return nil
+}
+
+func (l Lock) find(name string) (PackageLock, bool) {
+	for _, pkgLock := range l.Packages {
+		if pkgLock.Name == name {
+			return pkgLock, true
+		}
+	}
+
+	return PackageLock{}, false
+}
+
+// LockDiff describes a single package's drift from its recorded lock entry.
+type LockDiff struct {
+	Name   string
+	Reason string
+
+	OldFingerprint string
+	NewFingerprint string
+}
+
+func (d LockDiff) String() string {
+	return fmt.Sprintf("package '%s' %s (%s -> %s)", d.Name, d.Reason, d.OldFingerprint, d.NewFingerprint)
+}
+
+// ReadWithLock behaves like Read, except that the resulting package's
+// fingerprint and dependency closure are checked against lock.
+// dependencyFingerprints must map each of the package's declared
+// dependency names to the fingerprint already computed for that
+// dependency - a DirReaderImpl only ever reads one package directory in
+// isolation, so it has no way to compute those itself; the caller walking
+// the whole release graph is the one that can. If the package's fingerprint
+// or its dependencies' fingerprints have drifted, ReadWithLock returns a
+// LockDiff describing what changed and fails the read, unless updateLock
+// is true, in which case the drift is reported but not treated as an error
+// (the caller is expected to persist a refreshed Lock via WriteLock).
+func (r DirReaderImpl) ReadWithLock(
+	path string,
+	lock Lock,
+	dependencyFingerprints map[string]string,
+	updateLock bool,
+) (*Package, *LockDiff, error) {
+	pkg, err := r.Read(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolvedDependencyFingerprints := make([]string, len(pkg.Dependencies))
+	for i, dependencyName := range pkg.Dependencies {
+		fp, found := dependencyFingerprints[dependencyName]
+		if !found {
+			return nil, nil, bosherr.Errorf(
+				"Missing fingerprint for dependency '%s' of package '%s'", dependencyName, pkg.Name())
+		}
+		resolvedDependencyFingerprints[i] = fp
+	}
+	sort.Strings(resolvedDependencyFingerprints)
+
+	pkgLock, found := lock.find(pkg.Name())
+	if !found {
+		diff := &LockDiff{Name: pkg.Name(), Reason: "package added", NewFingerprint: pkg.Fingerprint()}
+		if !updateLock {
+			return nil, diff, bosherr.Errorf("%s; re-run with --update-lock to add it", diff)
+		}
+		return pkg, diff, nil
+	}
+
+	reason := diffReason(pkgLock.DependencyFingerprints, resolvedDependencyFingerprints)
+	if pkgLock.Fingerprint == pkg.Fingerprint() && reason != "dependency closure changed" {
+		return pkg, nil, nil
+	}
+
+	diff := &LockDiff{
+		Name:           pkg.Name(),
+		Reason:         reason,
+		OldFingerprint: pkgLock.Fingerprint,
+		NewFingerprint: pkg.Fingerprint(),
+	}
+
+	if !updateLock {
+		return nil, diff, bosherr.Errorf("%s; re-run with --update-lock to accept it", diff)
+	}
+
+	return pkg, diff, nil
+}
+
+// diffReason gives a best-effort explanation for why a fingerprint drifted,
+// based on what's visible from outside the archive: whether the sorted
+// list of dependency fingerprints itself changed - whether from a
+// dependency being added/removed/renamed, or an existing dependency's own
+// content drifting under the same name - vs. something internal to the
+// package (its files, or its pre/post scripts, all of which feed the
+// package's own fingerprint; dependencies feed it by name only).
+func diffReason(oldDependencyFingerprints, newDependencyFingerprints []string) string {
+	if len(oldDependencyFingerprints) != len(newDependencyFingerprints) {
+		return "dependency closure changed"
+	}
+
+	for i := range oldDependencyFingerprints {
+		if oldDependencyFingerprints[i] != newDependencyFingerprints[i] {
+			return "dependency closure changed"
+		}
+	}
+
+	return "files or pre/post scripts changed"
+}
@@ -0,0 +1,102 @@
+package pkg_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/cloudfoundry/bosh-init/release/pkg"
+	. "github.com/cloudfoundry/bosh-init/release/resource"
+)
+
+var _ = Describe("LockDiff", func() {
+	Describe("String", func() {
+		It("describes the package and fingerprint drift", func() {
+			diff := LockDiff{
+				Name:           "pkg1",
+				Reason:         "dependency closure changed",
+				OldFingerprint: "fp1",
+				NewFingerprint: "fp2",
+			}
+
+			Expect(diff.String()).To(Equal(
+				"package 'pkg1' dependency closure changed (fp1 -> fp2)"))
+		})
+	})
+})
+
+var _ = Describe("DirReaderImpl.ReadWithLock", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		reader DirReaderImpl
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		fs.WriteFileString("/release/packages/pkg1/spec", "---\nname: pkg1\ndependencies: [dep1]\n")
+		fs.WriteFileString("/release/packages/pkg1/packaging", "do-the-packaging")
+
+		reader = NewDirReaderImpl(NewArchiveFunc(fs), "/release/src", "/release/blobs", fs)
+	})
+
+	readPkg := func() *Package {
+		pkg, err := reader.Read("/release/packages/pkg1")
+		Expect(err).ToNot(HaveOccurred())
+		return pkg
+	}
+
+	It("reports a missing package as added and fails the read unless updateLock is set", func() {
+		_, diff, err := reader.ReadWithLock(
+			"/release/packages/pkg1", Lock{}, map[string]string{"dep1": "depfp"}, false)
+		Expect(err).To(HaveOccurred())
+		Expect(diff.Reason).To(Equal("package added"))
+
+		pkg, diff, err := reader.ReadWithLock(
+			"/release/packages/pkg1", Lock{}, map[string]string{"dep1": "depfp"}, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diff.Reason).To(Equal("package added"))
+		Expect(pkg.Name()).To(Equal("pkg1"))
+	})
+
+	It("finds no drift when the recorded fingerprint still matches", func() {
+		pkg := readPkg()
+		lock := Lock{Packages: []PackageLock{
+			{Name: "pkg1", Fingerprint: pkg.Fingerprint(), DependencyFingerprints: []string{"depfp"}},
+		}}
+
+		drifted, diff, err := reader.ReadWithLock(
+			"/release/packages/pkg1", lock, map[string]string{"dep1": "depfp"}, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diff).To(BeNil())
+		Expect(drifted.Name()).To(Equal("pkg1"))
+	})
+
+	It("reports a dependency closure change when a dependency's own fingerprint drifts under the same name", func() {
+		lock := Lock{Packages: []PackageLock{
+			{Name: "pkg1", Fingerprint: "stale-fingerprint", DependencyFingerprints: []string{"dep1-old-fp"}},
+		}}
+
+		_, diff, err := reader.ReadWithLock(
+			"/release/packages/pkg1", lock, map[string]string{"dep1": "dep1-new-fp"}, false)
+		Expect(err).To(HaveOccurred())
+		Expect(diff.Reason).To(Equal("dependency closure changed"))
+	})
+
+	It("reports files or scripts changed when the dependency closure is unchanged", func() {
+		lock := Lock{Packages: []PackageLock{
+			{Name: "pkg1", Fingerprint: "stale-fingerprint", DependencyFingerprints: []string{"dep1-fp"}},
+		}}
+
+		_, diff, err := reader.ReadWithLock(
+			"/release/packages/pkg1", lock, map[string]string{"dep1": "dep1-fp"}, false)
+		Expect(err).To(HaveOccurred())
+		Expect(diff.Reason).To(Equal("files or pre/post scripts changed"))
+	})
+
+	It("fails when the caller didn't supply a fingerprint for a declared dependency", func() {
+		_, _, err := reader.ReadWithLock("/release/packages/pkg1", Lock{}, map[string]string{}, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Missing fingerprint for dependency 'dep1'"))
+	})
+})
@@ -0,0 +1,50 @@
+package manifest
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+)
+
+type Manifest struct {
+	Name string
+
+	Dependencies []string
+
+	Files         []string
+	ExcludedFiles []string `yaml:"excluded_files"`
+
+	// PreInstall and PostInstall are inline command lists that run on the
+	// agent at the respective lifecycle points. They are an alternative to
+	// shipping a `pre_install`/`post_install` script file alongside the
+	// package's `packaging`/`pre_packaging` scripts.
+	PreInstall  []string `yaml:"pre_install"`
+	PostInstall []string `yaml:"post_install"`
+
+	Symlinks []Symlink `yaml:"symlinks"`
+}
+
+// Symlink declares a link that should be created at Name, pointing at
+// Target, when the package is installed. Unlike files picked up via the
+// `files` glob, symlinks are listed explicitly so that where a link points
+// is part of the package manifest, not an accident of the build machine.
+type Symlink struct {
+	Name   string `yaml:"name"`
+	Target string `yaml:"target"`
+}
+
+func NewManifestFromPath(path string, fs boshsys.FileSystem) (Manifest, error) {
+	var manifest Manifest
+
+	bytes, err := fs.ReadFile(path)
+	if err != nil {
+		return manifest, bosherr.WrapErrorf(err, "Reading package spec '%s'", path)
+	}
+
+	err = yaml.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return manifest, bosherr.WrapErrorf(err, "Parsing package spec '%s'", path)
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,182 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	gopath "path"
+	"strings"
+	"time"
+)
+
+// MemSourceFS is an in-memory SourceFS, used to unit test glob edge cases
+// (case sensitivity, symlink cycles, hidden files, `**`/`{a,b}` patterns)
+// without needing a real directory tree on disk.
+type MemSourceFS struct {
+	files    map[string][]byte
+	symlinks map[string]string
+	dirs     map[string]bool
+}
+
+func NewMemSourceFS() *MemSourceFS {
+	return &MemSourceFS{
+		files:    map[string][]byte{},
+		symlinks: map[string]string{},
+		dirs:     map[string]bool{"/": true},
+	}
+}
+
+func (fs *MemSourceFS) WriteFile(path string, contents []byte) {
+	fs.files[path] = contents
+	fs.markParents(path)
+}
+
+func (fs *MemSourceFS) WriteSymlink(path, target string) {
+	fs.symlinks[path] = target
+	fs.markParents(path)
+}
+
+func (fs *MemSourceFS) markParents(path string) {
+	dir := gopath.Dir(path)
+	for {
+		fs.dirs[dir] = true
+		if dir == "/" {
+			break
+		}
+		dir = gopath.Dir(dir)
+	}
+}
+
+func (fs *MemSourceFS) Glob(pattern string) ([]string, error) {
+	return globPattern(fs, pattern)
+}
+
+func (fs *MemSourceFS) Lstat(path string) (os.FileInfo, error) {
+	if target, ok := fs.symlinks[path]; ok {
+		return memFileInfo{name: gopath.Base(path), mode: os.ModeSymlink, target: target}, nil
+	}
+	if contents, ok := fs.files[path]; ok {
+		return memFileInfo{name: gopath.Base(path), size: int64(len(contents))}, nil
+	}
+	if fs.dirs[path] {
+		return memFileInfo{name: gopath.Base(path), mode: os.ModeDir}, nil
+	}
+
+	return nil, os.ErrNotExist
+}
+
+func (fs *MemSourceFS) Open(path string) (io.ReadCloser, error) {
+	contents, ok := fs.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(contents)), nil
+}
+
+func (fs *MemSourceFS) Readlink(path string) (string, error) {
+	target, ok := fs.symlinks[path]
+	if !ok {
+		return "", errors.New("Not a symlink: " + path)
+	}
+
+	return target, nil
+}
+
+// maxSymlinkHops bounds how many symlink hops IsDir will follow, much as a
+// real filesystem bounds symlink resolution. Unlike a real filesystem, which
+// reports ELOOP, IsDir reports a symlink that points at itself, or a chain
+// longer than this, as not a directory and returns no error.
+const maxSymlinkHops = 10
+
+// IsDir reports whether path is a directory, following symlinks the way
+// os.Stat would - including a symlink that points at a real directory
+// elsewhere in the tree, so tests can represent that case (a directory
+// symlink cycle is a property of how a caller *walks* the tree, not of any
+// single IsDir call, and is covered by the glob engine's own visited-set
+// guard rather than here).
+func (fs *MemSourceFS) IsDir(path string) (bool, error) {
+	for hop := 0; hop < maxSymlinkHops; hop++ {
+		if fs.dirs[path] {
+			return true, nil
+		}
+		if _, ok := fs.files[path]; ok {
+			return false, nil
+		}
+
+		target, ok := fs.symlinks[path]
+		if !ok {
+			return false, os.ErrNotExist
+		}
+
+		// A symlink's target is commonly relative to its own directory,
+		// not to the root, matching real filesystem semantics.
+		if !gopath.IsAbs(target) {
+			target = gopath.Join(gopath.Dir(path), target)
+		}
+		if target == path {
+			return false, nil
+		}
+
+		path = target
+	}
+
+	return false, nil
+}
+
+// listDir lists the immediate entries (names, not full paths) of dir. It
+// backs the shared glob engine in glob.go.
+func (fs *MemSourceFS) listDir(dir string) ([]string, error) {
+	prefix := dir
+	if prefix != "/" {
+		prefix += "/"
+	}
+
+	seen := map[string]bool{}
+	var names []string
+
+	add := func(path string) {
+		if !strings.HasPrefix(path, prefix) {
+			return
+		}
+
+		rest := strings.TrimPrefix(path, prefix)
+		if rest == "" {
+			return
+		}
+
+		name := strings.SplitN(rest, "/", 2)[0]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for path := range fs.files {
+		add(path)
+	}
+	for path := range fs.symlinks {
+		add(path)
+	}
+	for path := range fs.dirs {
+		add(path)
+	}
+
+	return names, nil
+}
+
+type memFileInfo struct {
+	name   string
+	size   int64
+	mode   os.FileMode
+	target string
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() os.FileMode  { return i.mode }
+func (i memFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,228 @@
+package pkg
+
+import (
+	"os"
+	gopath "path"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"encoding/json"
+)
+
+func matchGlob(pattern, path string) (bool, error) {
+	return gopath.Match(pattern, path)
+}
+
+// Overlay redirects specific logical paths under src/ or blobs/ to files
+// elsewhere on disk, modeled on the `-overlay` flag supported by `go build`.
+// It never mutates the underlying release tree.
+type Overlay struct {
+	Replace map[string]string
+}
+
+func NewOverlayFromPath(path string, fs boshsys.FileSystem) (Overlay, error) {
+	var overlay Overlay
+
+	bytes, err := fs.ReadFile(path)
+	if err != nil {
+		return overlay, bosherr.WrapErrorf(err, "Reading overlay '%s'", path)
+	}
+
+	err = json.Unmarshal(bytes, &overlay)
+	if err != nil {
+		return overlay, bosherr.WrapErrorf(err, "Parsing overlay '%s'", path)
+	}
+
+	return overlay, nil
+}
+
+func (o Overlay) replacementFor(path string) (string, bool) {
+	replacement, found := o.Replace[path]
+	return replacement, found
+}
+
+// overlayFS wraps a boshsys.FileSystem and answers Stat, Lstat, ReadFile,
+// Glob and RecursiveGlob for both real and overlaid entries, so that the
+// rest of the release/pkg pipeline - including the SourceFS glob engine,
+// which expands patterns via single-level Glob calls rather than
+// RecursiveGlob - can keep treating it as an ordinary file system.
+type overlayFS struct {
+	boshsys.FileSystem
+
+	overlay Overlay
+}
+
+func newOverlayFS(fs boshsys.FileSystem, overlay Overlay) overlayFS {
+	return overlayFS{FileSystem: fs, overlay: overlay}
+}
+
+func (fs overlayFS) Stat(path string) (os.FileInfo, error) {
+	if replacement, found := fs.overlay.replacementFor(path); found {
+		return os.Stat(replacement)
+	}
+
+	info, err := fs.FileSystem.Stat(path)
+	if err == nil {
+		return info, nil
+	}
+	if fs.isOverlayDir(path) {
+		return overlayDirInfo{name: gopath.Base(path)}, nil
+	}
+
+	return info, err
+}
+
+func (fs overlayFS) Lstat(path string) (os.FileInfo, error) {
+	if replacement, found := fs.overlay.replacementFor(path); found {
+		return os.Lstat(replacement)
+	}
+
+	info, err := fs.FileSystem.Lstat(path)
+	if err == nil {
+		return info, nil
+	}
+	if fs.isOverlayDir(path) {
+		return overlayDirInfo{name: gopath.Base(path)}, nil
+	}
+
+	return info, err
+}
+
+// mergeOverlayChildren adds the immediate child of dir implied by every
+// overlay replacement path nested under it, even when dir itself has no
+// entry anywhere in the real tree - that's what lets listDir, walking one
+// directory level at a time, eventually reach a file several levels under
+// an overlay-only subdirectory.
+func (fs overlayFS) mergeOverlayChildren(matches []string, dir string) []string {
+	matchesSet := map[string]struct{}{}
+	for _, match := range matches {
+		matchesSet[match] = struct{}{}
+	}
+
+	prefix := dir
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	for replacedPath := range fs.overlay.Replace {
+		if !strings.HasPrefix(replacedPath, prefix) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(replacedPath, prefix)
+		child := strings.SplitN(rest, "/", 2)[0]
+		matchesSet[prefix+child] = struct{}{}
+	}
+
+	result := make([]string, 0, len(matchesSet))
+	for match := range matchesSet {
+		result = append(result, match)
+	}
+
+	return result
+}
+
+// isOverlayDir reports whether path is a directory that exists only
+// virtually, as the ancestor of some overlay replacement path, with no
+// entry of its own anywhere in the real tree. This lets a caller walking
+// the tree one directory level at a time (as the SourceFS glob engine
+// does) descend into - and eventually reach - an overlaid file nested
+// under a subdirectory that was never part of the release.
+func (fs overlayFS) isOverlayDir(path string) bool {
+	prefix := path
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	for replacedPath := range fs.overlay.Replace {
+		if strings.HasPrefix(replacedPath, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// overlayDirInfo stands in for os.FileInfo on a directory that isOverlayDir
+// reports as existing only virtually.
+type overlayDirInfo struct {
+	name string
+}
+
+func (i overlayDirInfo) Name() string       { return i.name }
+func (i overlayDirInfo) Size() int64        { return 0 }
+func (i overlayDirInfo) Mode() os.FileMode  { return os.ModeDir }
+func (i overlayDirInfo) ModTime() time.Time { return time.Time{} }
+func (i overlayDirInfo) IsDir() bool        { return true }
+func (i overlayDirInfo) Sys() interface{}   { return nil }
+
+func (fs overlayFS) ReadFile(path string) ([]byte, error) {
+	if replacement, found := fs.overlay.replacementFor(path); found {
+		contents, err := os.ReadFile(replacement)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading overlaid file '%s'", replacement)
+		}
+
+		return contents, nil
+	}
+
+	return fs.FileSystem.ReadFile(path)
+}
+
+func (fs overlayFS) Glob(pattern string) ([]string, error) {
+	matches, err := fs.FileSystem.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// diskSourceFS.listDir only ever calls Glob with a "<dir>/*" pattern, one
+	// directory level at a time. mergeOverlayMatches alone can't surface an
+	// overlay path nested several levels under dir unless dir already exists
+	// in the real tree, since the intermediate directory name would never
+	// appear in any single-level Glob call; mergeOverlayChildren synthesizes
+	// those intermediate names directly from the overlay's Replace keys.
+	if dir := strings.TrimSuffix(pattern, "/*"); dir != pattern {
+		return fs.mergeOverlayChildren(matches, dir), nil
+	}
+
+	return fs.mergeOverlayMatches(matches, pattern)
+}
+
+func (fs overlayFS) RecursiveGlob(pattern string) ([]string, error) {
+	matches, err := fs.FileSystem.RecursiveGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.mergeOverlayMatches(matches, pattern)
+}
+
+// mergeOverlayMatches adds any overlay replacement paths matching pattern
+// to matches, so that an overlaid path that doesn't exist in the real tree
+// still turns up in glob results.
+func (fs overlayFS) mergeOverlayMatches(matches []string, pattern string) ([]string, error) {
+	matchesSet := map[string]struct{}{}
+	for _, match := range matches {
+		matchesSet[match] = struct{}{}
+	}
+
+	for path := range fs.overlay.Replace {
+		ok, err := matchGlob(pattern, path)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matchesSet[path] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(matchesSet))
+	for path := range matchesSet {
+		result = append(result, path)
+	}
+
+	return result, nil
+}
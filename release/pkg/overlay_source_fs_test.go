@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("overlayFS as a SourceFS", func() {
+	var fs *fakesys.FakeFileSystem
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+	})
+
+	It("surfaces an overlay-only path through Glob, not just RecursiveGlob", func() {
+		fs.WriteFileString("/release/src/foo/bar.rb", "bar")
+
+		overlay := Overlay{Replace: map[string]string{
+			"/release/src/foo/baz.rb": "/tmp/patched/baz.rb",
+		}}
+
+		matches, err := NewSourceFS(newOverlayFS(fs, overlay)).Glob("/release/src/foo/*.rb")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(matches).To(ConsistOf("/release/src/foo/bar.rb", "/release/src/foo/baz.rb"))
+	})
+
+	It("surfaces an overlay path nested under a subdirectory that doesn't exist on the real source tree", func() {
+		fs.WriteFileString("/release/src/foo/bar.rb", "bar")
+
+		overlay := Overlay{Replace: map[string]string{
+			"/release/src/newdir/baz.rb": "/tmp/patched/baz.rb",
+		}}
+
+		matches, err := NewSourceFS(newOverlayFS(fs, overlay)).Glob("/release/src/**/*.rb")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(matches).To(ConsistOf("/release/src/foo/bar.rb", "/release/src/newdir/baz.rb"))
+	})
+
+	It("Lstats an overlay-only path against its replacement, not the underlying fs", func() {
+		tmpFile, err := ioutil.TempFile("", "overlay-target")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(tmpFile.Name())
+
+		_, err = tmpFile.WriteString("patched contents")
+		Expect(err).ToNot(HaveOccurred())
+		tmpFile.Close()
+
+		overlay := Overlay{Replace: map[string]string{
+			"/release/src/foo/baz.rb": tmpFile.Name(),
+		}}
+
+		info, err := NewSourceFS(newOverlayFS(fs, overlay)).Lstat("/release/src/foo/baz.rb")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.IsDir()).To(BeFalse())
+	})
+})
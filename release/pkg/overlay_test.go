@@ -0,0 +1,40 @@
+package pkg_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	. "github.com/cloudfoundry/bosh-init/release/pkg"
+)
+
+var _ = Describe("NewOverlayFromPath", func() {
+	var fs *fakesys.FakeFileSystem
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+	})
+
+	It("parses the Replace mapping", func() {
+		fs.WriteFileString("/overlay.json", `{"Replace": {"src/foo/bar.rb": "/tmp/patched/bar.rb"}}`)
+
+		overlay, err := NewOverlayFromPath("/overlay.json", fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(overlay.Replace).To(Equal(map[string]string{
+			"src/foo/bar.rb": "/tmp/patched/bar.rb",
+		}))
+	})
+
+	It("returns an error if the overlay cannot be read", func() {
+		_, err := NewOverlayFromPath("/missing.json", fs)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error if the overlay is not valid JSON", func() {
+		fs.WriteFileString("/overlay.json", `not-json`)
+
+		_, err := NewOverlayFromPath("/overlay.json", fs)
+		Expect(err).To(HaveOccurred())
+	})
+})
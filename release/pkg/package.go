@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	. "github.com/cloudfoundry/bosh-init/release/resource"
+)
+
+type Package struct {
+	Resource
+
+	Dependencies []string
+
+	preInstall  []string
+	postInstall []string
+
+	preInstallScript  bool
+	postInstallScript bool
+}
+
+func NewPackage(resource Resource, dependencies []string) *Package {
+	return &Package{Resource: resource, Dependencies: dependencies}
+}
+
+func NewPackageWithHooks(
+	resource Resource,
+	dependencies []string,
+	preInstall, postInstall []string,
+	preInstallScript, postInstallScript bool,
+) *Package {
+	pkg := NewPackage(resource, dependencies)
+	pkg.preInstall = preInstall
+	pkg.postInstall = postInstall
+	pkg.preInstallScript = preInstallScript
+	pkg.postInstallScript = postInstallScript
+	return pkg
+}
+
+// PreInstall returns the commands the agent should run before this package
+// is installed, in order.
+func (p Package) PreInstall() []string { return p.preInstall }
+
+// PostInstall returns the commands the agent should run after this package
+// is installed, in order.
+func (p Package) PostInstall() []string { return p.postInstall }
+
+// HasPreInstallScript reports whether the package ships a `pre_install`
+// script file alongside its `packaging`/`pre_packaging` scripts, so the
+// agent-side compilation step knows to invoke it at the right lifecycle
+// point in addition to (or instead of) any inline PreInstall commands.
+func (p Package) HasPreInstallScript() bool { return p.preInstallScript }
+
+// HasPostInstallScript reports whether the package ships a `post_install`
+// script file, analogous to HasPreInstallScript.
+func (p Package) HasPostInstallScript() bool { return p.postInstallScript }
@@ -0,0 +1,29 @@
+package pkg
+
+import (
+	gopath "path"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// normalizeGlob canonicalizes a glob pattern taken from a package spec's
+// `files`/`excluded_files`/`symlinks` keys to use forward slashes, so that a
+// pattern written as `src\foo\*.rb` on Windows matches the same files (and
+// produces the same fingerprint) as `src/foo/*.rb` written on Linux. It
+// rejects patterns that are absolute or that escape the package directory
+// via `..`, since either would reach outside of src/ or blobs/.
+func normalizeGlob(glob string) (string, error) {
+	normalized := strings.Replace(glob, `\`, "/", -1)
+
+	if gopath.IsAbs(normalized) {
+		return "", bosherr.Errorf("Package glob '%s' must not be an absolute path", glob)
+	}
+
+	cleaned := gopath.Clean(normalized)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", bosherr.Errorf("Package glob '%s' must not escape the package directory", glob)
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("normalizeGlob", func() {
+	It("passes forward-slash globs through unchanged", func() {
+		normalized, err := normalizeGlob("src/foo/*.rb")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(normalized).To(Equal("src/foo/*.rb"))
+	})
+
+	It("canonicalizes backslash-separated globs to forward slashes", func() {
+		normalized, err := normalizeGlob(`src\foo\*.rb`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(normalized).To(Equal("src/foo/*.rb"))
+	})
+
+	It("rejects an absolute glob", func() {
+		_, err := normalizeGlob("/etc/passwd")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("must not be an absolute path"))
+	})
+
+	It("rejects a glob that escapes the package directory", func() {
+		_, err := normalizeGlob("../../etc/passwd")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("must not escape the package directory"))
+	})
+})
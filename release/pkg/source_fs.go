@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	gopath "path"
+	"path/filepath"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// SourceFS is the narrow filesystem surface the package reader needs in
+// order to expand glob patterns and resolve symlinks. Depending on
+// boshsys.FileSystem directly makes edge cases (case sensitivity, symlink
+// cycles, hidden files) hard to exercise in a unit test; SourceFS lets an
+// in-memory tree stand in for the real disk.
+type SourceFS interface {
+	Glob(pattern string) ([]string, error)
+	Lstat(path string) (os.FileInfo, error)
+	Open(path string) (io.ReadCloser, error)
+	Readlink(path string) (string, error)
+	IsDir(path string) (bool, error)
+}
+
+// diskSourceFS is the default SourceFS, backed by a real boshsys.FileSystem.
+type diskSourceFS struct {
+	fs boshsys.FileSystem
+}
+
+func NewSourceFS(fs boshsys.FileSystem) SourceFS {
+	return diskSourceFS{fs: fs}
+}
+
+func (s diskSourceFS) Glob(pattern string) ([]string, error) {
+	return globPattern(s, pattern)
+}
+
+func (s diskSourceFS) Lstat(path string) (os.FileInfo, error) {
+	return s.fs.Lstat(path)
+}
+
+func (s diskSourceFS) Open(path string) (io.ReadCloser, error) {
+	contents, err := s.fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(contents)), nil
+}
+
+func (s diskSourceFS) Readlink(path string) (string, error) {
+	return s.fs.Readlink(path)
+}
+
+func (s diskSourceFS) IsDir(path string) (bool, error) {
+	info, err := s.fs.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	return info.IsDir(), nil
+}
+
+// listDir lists the immediate entries (names, not full paths) of dir. It
+// backs the shared glob engine in glob.go. Matches are canonicalized to
+// forward slashes before taking their base name, since the underlying
+// boshsys.FileSystem may return backslash-separated paths on Windows.
+func (s diskSourceFS) listDir(dir string) ([]string, error) {
+	matches, err := s.fs.Glob(gopath.Join(dir, "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(matches))
+	for i, match := range matches {
+		names[i] = gopath.Base(filepath.ToSlash(match))
+	}
+
+	return names, nil
+}
@@ -0,0 +1,153 @@
+package resource
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type ArchiveFunc func(files, prepFiles []File, chunks []string) Archive
+
+type Archive interface {
+	Fingerprint() (string, error)
+	Build(stageDirPath string) (string, int64, error)
+}
+
+type archiveImpl struct {
+	files     []File
+	prepFiles []File
+	chunks    []string
+
+	fs boshsys.FileSystem
+}
+
+func NewArchiveFunc(fs boshsys.FileSystem) ArchiveFunc {
+	return func(files, prepFiles []File, chunks []string) Archive {
+		return archiveImpl{files: files, prepFiles: prepFiles, chunks: chunks, fs: fs}
+	}
+}
+
+func (a archiveImpl) Fingerprint() (string, error) {
+	hash := sha1.New()
+
+	all := a.sortedFiles()
+
+	for _, file := range all {
+		hash.Write([]byte(file.RelativePath))
+
+		if file.Symlink {
+			// A symlink's target, not its resolved contents, determines what
+			// gets installed, so a target flip must change the fingerprint.
+			hash.Write([]byte(file.LinkTarget))
+			continue
+		}
+
+		contents, err := a.fs.ReadFile(file.Path)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Reading file '%s' for fingerprint", file.Path)
+		}
+
+		hash.Write(contents)
+	}
+
+	chunks := append([]string{}, a.chunks...)
+	sort.Strings(chunks)
+
+	for _, chunk := range chunks {
+		hash.Write([]byte(chunk))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (a archiveImpl) Build(stageDirPath string) (string, int64, error) {
+	archivePath := filepath.Join(stageDirPath, "archive.tgz")
+
+	archiveFile, err := os.Create(archivePath)
+	if err != nil {
+		return "", 0, bosherr.WrapErrorf(err, "Creating archive '%s'", archivePath)
+	}
+	defer archiveFile.Close()
+
+	gzWriter := gzip.NewWriter(archiveFile)
+	defer gzWriter.Close()
+
+	tarWriter := tar.NewWriter(gzWriter)
+	defer tarWriter.Close()
+
+	for _, file := range a.sortedFiles() {
+		err := a.writeFile(tarWriter, file)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return "", 0, bosherr.WrapErrorf(err, "Closing archive '%s'", archivePath)
+	}
+
+	info, err := archiveFile.Stat()
+	if err != nil {
+		return "", 0, bosherr.WrapErrorf(err, "Stat'ing archive '%s'", archivePath)
+	}
+
+	return archivePath, info.Size(), nil
+}
+
+func (a archiveImpl) writeFile(tarWriter *tar.Writer, file File) error {
+	if file.Symlink {
+		header := &tar.Header{
+			Typeflag: tar.TypeSymlink,
+			Name:     file.RelativePath,
+			Linkname: file.LinkTarget,
+			Mode:     0777,
+		}
+
+		err := tarWriter.WriteHeader(header)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing archive symlink header for '%s'", file.RelativePath)
+		}
+
+		return nil
+	}
+
+	contents, err := a.fs.ReadFile(file.Path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading file '%s' for archive", file.Path)
+	}
+
+	header := &tar.Header{
+		Name: file.RelativePath,
+		Mode: 0644,
+		Size: int64(len(contents)),
+	}
+
+	err = tarWriter.WriteHeader(header)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing archive header for '%s'", file.RelativePath)
+	}
+
+	_, err = tarWriter.Write(contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing archive contents for '%s'", file.RelativePath)
+	}
+
+	return nil
+}
+
+func (a archiveImpl) sortedFiles() []File {
+	all := append([]File{}, a.files...)
+	all = append(all, a.prepFiles...)
+
+	sort.Slice(all, func(i, j int) bool { return all[i].RelativePath < all[j].RelativePath })
+
+	return all
+}
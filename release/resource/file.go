@@ -0,0 +1,38 @@
+package resource
+
+import (
+	"path/filepath"
+)
+
+type File struct {
+	Path    string
+	DirPath string
+
+	RelativePath string
+
+	ExcludeMode bool
+
+	// Symlink and LinkTarget record that this entry is a symbolic link
+	// rather than a regular file, so that the archive step can preserve it
+	// as a link instead of copying the resolved contents.
+	Symlink    bool
+	LinkTarget string
+}
+
+func NewFile(path, dirPath string) File {
+	relPath, _ := filepath.Rel(dirPath, path)
+
+	return File{
+		Path:    path,
+		DirPath: dirPath,
+
+		RelativePath: filepath.ToSlash(relPath),
+	}
+}
+
+func NewSymlinkFile(path, dirPath, target string) File {
+	file := NewFile(path, dirPath)
+	file.Symlink = true
+	file.LinkTarget = target
+	return file
+}
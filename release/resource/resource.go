@@ -0,0 +1,16 @@
+package resource
+
+type Resource struct {
+	name        string
+	fingerprint string
+
+	archive Archive
+}
+
+func NewResource(name, fingerprint string, archive Archive) Resource {
+	return Resource{name: name, fingerprint: fingerprint, archive: archive}
+}
+
+func (r Resource) Name() string        { return r.name }
+func (r Resource) Fingerprint() string { return r.fingerprint }
+func (r Resource) Archive() Archive    { return r.archive }